@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewPasswordHashVerifyPasswordRoundTrip(t *testing.T) {
+	encoded := NewPasswordHash("correct-horse-battery-staple")
+
+	valid, err := VerifyPassword(encoded, "correct-horse-battery-staple")
+	if err != nil {
+		t.Fatalf("VerifyPassword: %v", err)
+	}
+	if !valid {
+		t.Fatal("VerifyPassword: want true for the correct password")
+	}
+}
+
+func TestVerifyPasswordWrongPassword(t *testing.T) {
+	encoded := NewPasswordHash("correct-horse-battery-staple")
+
+	valid, err := VerifyPassword(encoded, "wrong-password")
+	if err != nil {
+		t.Fatalf("VerifyPassword: %v", err)
+	}
+	if valid {
+		t.Fatal("VerifyPassword: want false for the wrong password")
+	}
+}
+
+func TestNewPasswordHashUniqueSalt(t *testing.T) {
+	a := NewPasswordHash("same-password")
+	b := NewPasswordHash("same-password")
+	if a == b {
+		t.Fatal("NewPasswordHash: two hashes of the same password should differ due to random salts")
+	}
+}
+
+func TestVerifyPasswordMalformedHash(t *testing.T) {
+	if _, err := VerifyPassword("not-an-argon2-hash", "whatever"); err == nil {
+		t.Fatal("VerifyPassword: want error for malformed encoded hash")
+	}
+}
+
+func TestMemoryUsersPasswordHash(t *testing.T) {
+	users := MemoryUsers{"alice": NewPasswordHash("s3cret")}
+
+	if _, ok := users.PasswordHash("bob"); ok {
+		t.Fatal("PasswordHash: want ok=false for unknown user")
+	}
+
+	encoded, ok := users.PasswordHash("alice")
+	if !ok {
+		t.Fatal("PasswordHash: want ok=true for known user")
+	}
+	valid, err := VerifyPassword(encoded, "s3cret")
+	if err != nil || !valid {
+		t.Fatalf("VerifyPassword: valid=%v err=%v", valid, err)
+	}
+}
+
+func TestRequireBasicAuthNoCredentials(t *testing.T) {
+	handler := RequireBasicAuth(MemoryUsers{"alice": NewPasswordHash("s3cret")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not be called without credentials")
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/products/1", nil)
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireBasicAuthUnknownUser(t *testing.T) {
+	handler := RequireBasicAuth(MemoryUsers{"alice": NewPasswordHash("s3cret")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not be called for an unknown user")
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/products/1", nil)
+	req.SetBasicAuth("bob", "whatever")
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireBasicAuthWrongPassword(t *testing.T) {
+	handler := RequireBasicAuth(MemoryUsers{"alice": NewPasswordHash("s3cret")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not be called for a wrong password")
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/products/1", nil)
+	req.SetBasicAuth("alice", "wrong-password")
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireBasicAuthValidCredentials(t *testing.T) {
+	called := false
+	handler := RequireBasicAuth(MemoryUsers{"alice": NewPasswordHash("s3cret")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/products/1", nil)
+	req.SetBasicAuth("alice", "s3cret")
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !called {
+		t.Fatal("next handler was not called for valid credentials")
+	}
+}
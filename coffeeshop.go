@@ -6,14 +6,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nats-io/nats.go"
 	"golang.org/x/exp/maps"
+
+	"github.com/jjngx/coffeeshop/auth"
 )
 
 // Product represents a product in the inventory.
@@ -57,26 +62,77 @@ func (p *Products) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Filter narrows down a List call to products matching all of its
+// non-zero fields. MinPrice/MaxPrice are inclusive and ignored when
+// zero.
+type Filter struct {
+	Brand    string
+	Type     string
+	MinPrice float64
+	MaxPrice float64
+
+	// Labels matches arbitrary label=value facets, as returned by
+	// LabelNames/LabelValues, in addition to Brand/Type.
+	Labels map[string]string
+}
+
+// Matches reports whether p satisfies every non-zero field of f.
+func (f Filter) Matches(p Product) bool {
+	if f.Brand != "" && p.Brand != f.Brand {
+		return false
+	}
+	if f.Type != "" && p.Type != f.Type {
+		return false
+	}
+	if f.MinPrice != 0 || f.MaxPrice != 0 {
+		price, err := strconv.ParseFloat(p.Price, 64)
+		if err != nil {
+			return false
+		}
+		if f.MinPrice != 0 && price < f.MinPrice {
+			return false
+		}
+		if f.MaxPrice != 0 && price > f.MaxPrice {
+			return false
+		}
+	}
+	for name, value := range f.Labels {
+		if !containsString(productLabelValues(p, name), value) {
+			return false
+		}
+	}
+	return true
+}
+
 // MemoryStore represents a storage for products
 // in the CoffeeShop.
 //
 // Use memory store for testing and development.
-// For production use a SQL or NoSQL database.
+// For production use Store implementations backed by a real
+// database, such as store/postgres or store/bolt.
 type MemoryStore struct {
 	mx       sync.RWMutex
 	Products Products
 }
 
-// GetAll returns all products in the store.
-func (ms *MemoryStore) GetAll() []Product {
+// GetAll returns all products in the store. It returns early if ctx is
+// canceled before the read completes.
+func (ms *MemoryStore) GetAll(ctx context.Context) []Product {
+	if ctx.Err() != nil {
+		return nil
+	}
 	ms.mx.RLock()
 	defer ms.mx.RUnlock()
 	return maps.Values(ms.Products)
 }
 
 // GetProduct takes id and returns the corresponding product.
-// It errors if the product with requested ID does not exist.
-func (ms *MemoryStore) GetProduct(id string) (Product, error) {
+// It errors if the product with requested ID does not exist, or if ctx
+// is canceled before the read completes.
+func (ms *MemoryStore) GetProduct(ctx context.Context, id string) (Product, error) {
+	if err := ctx.Err(); err != nil {
+		return Product{}, err
+	}
 	ms.mx.RLock()
 	defer ms.mx.RUnlock()
 	p, ok := ms.Products[id]
@@ -86,10 +142,51 @@ func (ms *MemoryStore) GetProduct(id string) (Product, error) {
 	return p, nil
 }
 
+// Put inserts p, or replaces the existing product with the same ID.
+func (ms *MemoryStore) Put(p Product) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if ms.Products == nil {
+		ms.Products = Products{}
+	}
+	ms.Products[p.ID] = p
+	return nil
+}
+
+// Delete removes the product with the given id.
+// It errors if the product with requested ID does not exist.
+func (ms *MemoryStore) Delete(id string) error {
+	ms.mx.Lock()
+	defer ms.mx.Unlock()
+	if _, ok := ms.Products[id]; !ok {
+		return errors.New("product not found")
+	}
+	delete(ms.Products, id)
+	return nil
+}
+
+// List returns the products matching filter.
+func (ms *MemoryStore) List(filter Filter) ([]Product, error) {
+	ms.mx.RLock()
+	defer ms.mx.RUnlock()
+	products := make([]Product, 0, len(ms.Products))
+	for _, p := range ms.Products {
+		if filter.Matches(p) {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
 // Store is an interface for product store.
 type Store interface {
-	GetAll() []Product
-	GetProduct(id string) (Product, error)
+	GetAll(ctx context.Context) []Product
+	GetProduct(ctx context.Context, id string) (Product, error)
+	Put(Product) error
+	Delete(id string) error
+	List(filter Filter) ([]Product, error)
+	LabelNames() []string
+	LabelValues(name string) []string
 }
 
 func latencyFromEnv(key, fallback string) time.Duration {
@@ -111,8 +208,42 @@ func latencyFromEnv(key, fallback string) time.Duration {
 type Server struct {
 	HTTPServer *http.Server
 	URL        string
-	Latency    time.Duration
 	Store      Store
+
+	// RequestTimeout bounds how long a request's context stays alive,
+	// analogous to net.Conn's deadlines but re-armed per request.
+	RequestTimeout time.Duration
+
+	latencyMx sync.RWMutex
+	latency   time.Duration
+
+	// JetStream, Orders and OrdersKV are non-nil once WithJetStream has
+	// been applied, enabling the order-taking routes.
+	JetStream nats.JetStreamContext
+	Orders    *OrderPublisher
+	OrdersKV  nats.KeyValue
+	natsConn  *nats.Conn
+
+	// authMiddleware, once set by WithAuth, guards mutating routes with
+	// HTTP Basic Auth.
+	authMiddleware func(http.Handler) http.Handler
+}
+
+// Latency returns the latency currently applied to every response by
+// the Delay middleware. Safe for concurrent use.
+func (cs *Server) Latency() time.Duration {
+	cs.latencyMx.RLock()
+	defer cs.latencyMx.RUnlock()
+	return cs.latency
+}
+
+// SetLatency changes the latency applied by the Delay middleware. It
+// can be called while the server is running, letting tests and
+// operators adjust latency without a restart.
+func (cs *Server) SetLatency(d time.Duration) {
+	cs.latencyMx.Lock()
+	defer cs.latencyMx.Unlock()
+	cs.latency = d
 }
 
 type option func(*Server) error
@@ -125,7 +256,55 @@ func WithLatency(latency string) option {
 		if err != nil {
 			return err
 		}
-		s.Latency = v
+		s.latency = v
+		return nil
+	}
+}
+
+// WithRequestTimeout bounds how long a request's context stays alive
+// before it's canceled, replacing the hardcoded 120s timeout.
+func WithRequestTimeout(d time.Duration) option {
+	return func(s *Server) error {
+		s.RequestTimeout = d
+		return nil
+	}
+}
+
+// WithReadDeadline sets how long the HTTP server waits to read a full
+// request, analogous to net.Conn's SetReadDeadline.
+func WithReadDeadline(d time.Duration) option {
+	return func(s *Server) error {
+		s.HTTPServer.ReadTimeout = d
+		return nil
+	}
+}
+
+// WithWriteDeadline sets how long the HTTP server waits to write a
+// response, analogous to net.Conn's SetWriteDeadline.
+func WithWriteDeadline(d time.Duration) option {
+	return func(s *Server) error {
+		s.HTTPServer.WriteTimeout = d
+		return nil
+	}
+}
+
+// WithAuth requires HTTP Basic Auth, checked against users, on every
+// mutating route (PUT/DELETE /products/{id}, POST /orders). Reads stay
+// open, so the shop can run publicly for browsing while requiring
+// credentials for inventory changes.
+func WithAuth(users auth.UserStore) option {
+	return func(s *Server) error {
+		s.authMiddleware = auth.RequireBasicAuth(users)
+		return nil
+	}
+}
+
+// WithStore overrides the Store passed to New, letting operators pick a
+// persistent backend (e.g. store/postgres or store/bolt) at startup
+// without recompiling.
+func WithStore(store Store) option {
+	return func(s *Server) error {
+		s.Store = store
 		return nil
 	}
 }
@@ -138,9 +317,10 @@ func New(addr string, store Store, options ...option) *Server {
 			ReadTimeout:  30 * time.Second,
 			WriteTimeout: 30 * time.Second,
 		},
-		URL:     fmt.Sprintf("http://%s/", addr),
-		Latency: latencyFromEnv("COFFEESHOP_LATENCY", "100ms"),
-		Store:   store,
+		URL:            fmt.Sprintf("http://%s/", addr),
+		RequestTimeout: 120 * time.Second,
+		latency:        latencyFromEnv("COFFEESHOP_LATENCY", "100ms"),
+		Store:          store,
 	}
 
 	for _, o := range options {
@@ -150,12 +330,24 @@ func New(addr string, store Store, options ...option) *Server {
 	return &srv
 }
 
-// Delay is a middleware to imtroduce response latency
-// on all routes implemented by CoffeeShop server.
-func Delay(d time.Duration) func(next http.Handler) http.Handler {
+// Delay is a middleware to introduce response latency on all routes
+// implemented by CoffeeShop server. latency is consulted on every
+// request, so SetLatency takes effect immediately, and the wait honors
+// r.Context().Done() so a client cancellation aborts it right away
+// instead of blocking a goroutine in time.Sleep.
+func Delay(latency func() time.Duration) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			time.Sleep(d)
+			d := latency()
+			if d > 0 {
+				timer := time.NewTimer(d)
+				defer timer.Stop()
+				select {
+				case <-r.Context().Done():
+					return
+				case <-timer.C:
+				}
+			}
 			next.ServeHTTP(w, r)
 		}
 		return http.HandlerFunc(fn)
@@ -166,24 +358,56 @@ func Delay(d time.Duration) func(next http.Handler) http.Handler {
 func (cs *Server) ListenAndServe() error {
 	mux := chi.NewRouter()
 	mux.Use(
-		middleware.Timeout(120*time.Second),
+		middleware.Timeout(cs.RequestTimeout),
 		middleware.SetHeader("Content-Type", "application/json; charset=utf-8"),
 		Delay(cs.Latency),
 	)
 	mux.Get("/products", cs.GetProducts)
+	mux.Get("/products/labels", cs.GetLabels)
+	mux.Get("/products/label/{name}/values", cs.GetLabelValues)
 	mux.Get("/products/{productID}", cs.GetProduct)
+	mux.Get("/orders/pending", cs.GetPendingOrders)
+
+	mux.Group(func(r chi.Router) {
+		if cs.authMiddleware != nil {
+			r.Use(cs.authMiddleware)
+		}
+		r.Put("/products/{productID}", cs.PutProduct)
+		r.Delete("/products/{productID}", cs.DeleteProduct)
+		r.Post("/orders", cs.PostOrder)
+	})
+
 	cs.HTTPServer.Handler = mux
 	return cs.HTTPServer.ListenAndServe()
 }
 
-// Shutdown terminates CoffeeShop server.
+// Shutdown terminates CoffeeShop server, draining the NATS connection
+// first so in-flight JetStream publishes and acks complete.
 func (cs *Server) Shutdown(ctx context.Context) error {
+	if cs.natsConn != nil {
+		if err := cs.natsConn.Drain(); err != nil {
+			return err
+		}
+	}
 	return cs.HTTPServer.Shutdown(ctx)
 }
 
-// GetProducts returns all products available in the coffeeshop store.
+// GetProducts returns the products available in the coffeeshop store,
+// narrowed down by the brand, type, min_price and max_price query-string
+// parameters and by repeated match[]=label=value parameters when present.
 func (cs *Server) GetProducts(w http.ResponseWriter, r *http.Request) {
-	products := cs.Store.GetAll()
+	filter, err := filterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := cs.Store.List(filter)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
 	data, err := json.MarshalIndent(products, "", "  ")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -194,11 +418,45 @@ func (cs *Server) GetProducts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func filterFromQuery(q url.Values) (Filter, error) {
+	filter := Filter{
+		Brand: q.Get("brand"),
+		Type:  q.Get("type"),
+	}
+	if v := q.Get("min_price"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Filter{}, errors.New("invalid min_price")
+		}
+		filter.MinPrice = p
+	}
+	if v := q.Get("max_price"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return Filter{}, errors.New("invalid max_price")
+		}
+		filter.MaxPrice = p
+	}
+
+	for _, match := range q["match[]"] {
+		name, value, ok := strings.Cut(match, "=")
+		if !ok {
+			return Filter{}, fmt.Errorf("invalid match[] %q, want label=value", match)
+		}
+		if filter.Labels == nil {
+			filter.Labels = map[string]string{}
+		}
+		filter.Labels[name] = value
+	}
+
+	return filter, nil
+}
+
 // GetProduct returns a single product from the coffeeshop store.
 // It errors if the product with given ID can't be found.
 func (cs *Server) GetProduct(w http.ResponseWriter, r *http.Request) {
 	productID := chi.URLParam(r, "productID")
-	product, err := cs.Store.GetProduct(productID)
+	product, err := cs.Store.GetProduct(r.Context(), productID)
 	if err != nil {
 		http.Error(w, "product not found", http.StatusNotFound)
 		return
@@ -214,6 +472,44 @@ func (cs *Server) GetProduct(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PutProduct creates or replaces the product identified by the
+// productID URL parameter with the request body.
+func (cs *Server) PutProduct(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productID")
+
+	var product Product
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		http.Error(w, "invalid product", http.StatusBadRequest)
+		return
+	}
+	product.ID = productID
+
+	if err := cs.Store.Put(product); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(product)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// DeleteProduct removes the product identified by the productID URL
+// parameter. It errors if the product with given ID can't be found.
+func (cs *Server) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productID")
+	if err := cs.Store.Delete(productID); err != nil {
+		http.Error(w, "product not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // Run creates and starts coffeeshop server with default, in-memory store.
 func Run() error {
 	store := MemoryStore{
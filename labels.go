@@ -0,0 +1,113 @@
+package coffeeshop
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// productLabelValues returns the values product p has for label name.
+// "brand" and "type" map directly onto their respective fields; any
+// other name is looked up among the product's Properties.
+func productLabelValues(p Product, name string) []string {
+	switch name {
+	case "brand":
+		return []string{p.Brand}
+	case "type":
+		return []string{p.Type}
+	default:
+		var values []string
+		for _, prop := range p.Properties {
+			if prop.Name == name {
+				values = append(values, prop.Value)
+			}
+		}
+		return values
+	}
+}
+
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// LabelNames returns the set of filterable field names across products:
+// "type", "brand", and every distinct Property.Name.
+func LabelNames(products []Product) []string {
+	names := map[string]struct{}{"type": {}, "brand": {}}
+	for _, p := range products {
+		for _, prop := range p.Properties {
+			names[prop.Name] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// LabelValues returns the distinct values seen for label name across
+// products.
+func LabelValues(products []Product, name string) []string {
+	values := map[string]struct{}{}
+	for _, p := range products {
+		for _, v := range productLabelValues(p, name) {
+			if v != "" {
+				values[v] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(values))
+	for v := range values {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// LabelNames returns the label names the store knows how to filter on.
+func (ms *MemoryStore) LabelNames() []string {
+	return LabelNames(ms.GetAll(context.Background()))
+}
+
+// LabelValues returns the distinct values seen for label name.
+func (ms *MemoryStore) LabelValues(name string) []string {
+	return LabelValues(ms.GetAll(context.Background()), name)
+}
+
+// GetLabels returns the label names a UI can build a facet browser from.
+func (cs *Server) GetLabels(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(cs.Store.LabelNames())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+// GetLabelValues returns the distinct values seen for the label named by
+// the name URL parameter.
+func (cs *Server) GetLabelValues(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	data, err := json.Marshal(cs.Store.LabelValues(name))
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
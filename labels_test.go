@@ -0,0 +1,92 @@
+package coffeeshop
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testProducts() []Product {
+	return []Product{
+		{
+			ID:    "1",
+			Type:  "Coffee",
+			Brand: "illy",
+			Price: "7.99",
+			Properties: []Property{
+				{Name: "intensity", Value: "Medium (6/10)"},
+			},
+		},
+		{
+			ID:    "2",
+			Type:  "Coffee",
+			Brand: "Lavazza",
+			Price: "12.99",
+			Properties: []Property{
+				{Name: "intensity", Value: "Strong (8/10)"},
+			},
+		},
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	products := testProducts()
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"no filter", Filter{}, []string{"1", "2"}},
+		{"brand", Filter{Brand: "illy"}, []string{"1"}},
+		{"type", Filter{Type: "Coffee"}, []string{"1", "2"}},
+		{"min price", Filter{MinPrice: 10}, []string{"2"}},
+		{"max price", Filter{MaxPrice: 10}, []string{"1"}},
+		{"price range", Filter{MinPrice: 7, MaxPrice: 8}, []string{"1"}},
+		{"label match", Filter{Labels: map[string]string{"intensity": "Strong (8/10)"}}, []string{"2"}},
+		{"label and brand", Filter{Brand: "illy", Labels: map[string]string{"intensity": "Strong (8/10)"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range products {
+				if tt.filter.Matches(p) {
+					got = append(got, p.ID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelNames(t *testing.T) {
+	got := LabelNames(testProducts())
+	want := []string{"brand", "intensity", "type"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LabelNames() = %v, want %v", got, want)
+	}
+}
+
+func TestLabelValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		want  []string
+	}{
+		{"brand", "brand", []string{"Lavazza", "illy"}},
+		{"property", "intensity", []string{"Medium (6/10)", "Strong (8/10)"}},
+		{"unknown", "not-a-label", []string{}},
+	}
+
+	products := testProducts()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LabelValues(products, tt.label)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LabelValues(%q) = %v, want %v", tt.label, got, tt.want)
+			}
+		})
+	}
+}
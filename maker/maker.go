@@ -0,0 +1,143 @@
+// Package maker implements CoffeeMaker workers: durable JetStream pull
+// consumers that brew Orders published by a coffeeshop.Server.
+package maker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/jjngx/coffeeshop"
+)
+
+// decrementRetries bounds how many attempts process makes at the
+// pending-count decrement before giving up and logging it.
+const decrementRetries = 3
+
+// BrewFunc prepares a single order. Returning an error naks the message
+// so JetStream redelivers it to a CoffeeMaker bound to the same durable
+// consumer, which may be the same one.
+type BrewFunc func(ctx context.Context, order coffeeshop.Order) error
+
+// CoffeeMaker binds a durable pull consumer for a single product and
+// brews the orders it receives, acking or naking each message depending
+// on the outcome of Brew.
+type CoffeeMaker struct {
+	ProductID string
+	Brew      BrewFunc
+
+	sub *nats.Subscription
+	kv  nats.KeyValue
+}
+
+// New creates (if needed) and binds a durable pull consumer on
+// streamName, filtered server-side to productID's subject via
+// FilterSubject, so that multiple CoffeeMaker instances for different
+// products share the same stream without stealing each other's orders.
+// The durable name is derived from consumerName and productID, so each
+// product gets its own consumer.
+func New(js nats.JetStreamContext, streamName, consumerName, productID string, brew BrewFunc) (*CoffeeMaker, error) {
+	durable := consumerName + "-" + productID
+	subject := coffeeshop.OrderSubject(productID)
+
+	if _, err := js.ConsumerInfo(streamName, durable); err != nil {
+		if _, err := js.AddConsumer(streamName, &nats.ConsumerConfig{
+			Durable:       durable,
+			FilterSubject: subject,
+			AckPolicy:     nats.AckExplicitPolicy,
+			DeliverPolicy: nats.DeliverAllPolicy,
+		}); err != nil {
+			return nil, fmt.Errorf("add consumer %s: %w", durable, err)
+		}
+	}
+
+	sub, err := js.PullSubscribe(subject, durable, nats.Bind(streamName, durable))
+	if err != nil {
+		return nil, fmt.Errorf("bind pull consumer %s: %w", durable, err)
+	}
+
+	kv, err := js.KeyValue(coffeeshop.OrdersValuesBucket)
+	if err != nil {
+		return nil, fmt.Errorf("orders-values bucket: %w", err)
+	}
+
+	return &CoffeeMaker{ProductID: productID, Brew: brew, sub: sub, kv: kv}, nil
+}
+
+// Run fetches orders in batches of batchSize until ctx is done, brewing
+// each one and acking or naking it explicitly.
+func (cm *CoffeeMaker) Run(ctx context.Context, batchSize int) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		msgs, err := cm.sub.Fetch(batchSize, nats.MaxWait(5*time.Second))
+		if err != nil {
+			if err == nats.ErrTimeout {
+				continue
+			}
+			return fmt.Errorf("fetch orders: %w", err)
+		}
+
+		for _, msg := range msgs {
+			cm.process(ctx, msg)
+		}
+	}
+}
+
+func (cm *CoffeeMaker) process(ctx context.Context, msg *nats.Msg) {
+	var order coffeeshop.Order
+	if err := json.Unmarshal(msg.Data, &order); err != nil {
+		_ = msg.Term()
+		return
+	}
+
+	if err := cm.Brew(ctx, order); err != nil {
+		_ = msg.Nak()
+		return
+	}
+
+	if err := msg.Ack(); err != nil {
+		log.Printf("maker: ack order %s: %v", order.ID, err)
+		return
+	}
+
+	var err error
+	for attempt := 0; attempt < decrementRetries; attempt++ {
+		if err = cm.decrementPending(); err == nil {
+			return
+		}
+	}
+	log.Printf("maker: decrement pending count for order %s after %d attempts: %v", order.ID, decrementRetries, err)
+}
+
+func (cm *CoffeeMaker) decrementPending() error {
+	for {
+		entry, err := cm.kv.Get(coffeeshop.OrdersPendingKey)
+		if err != nil {
+			return err
+		}
+		current, err := strconv.Atoi(string(entry.Value()))
+		if err != nil {
+			return err
+		}
+		next := current - 1
+		if next < 0 {
+			next = 0
+		}
+		_, err = cm.kv.Update(coffeeshop.OrdersPendingKey, []byte(strconv.Itoa(next)), entry.Revision())
+		if err != nil && errors.Is(err, nats.ErrKeyExists) {
+			continue
+		}
+		return err
+	}
+}
@@ -0,0 +1,220 @@
+package coffeeshop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// OrdersValuesBucket is the JetStream KeyValue bucket used to track
+// pending-order counts. CoffeeMaker workers use it to decrement the
+// count once an order has been brewed.
+const OrdersValuesBucket = "orders-values"
+
+// OrdersPendingKey is the KV key holding the total number of orders
+// that have been published but not yet acknowledged by a CoffeeMaker.
+const OrdersPendingKey = "orders.pending"
+
+// Order represents a customer order for a single product.
+type Order struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"productId"`
+	Quantity  int       `json:"quantity,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// OrderSubject returns the JetStream subject an order is published on.
+// CoffeeMaker workers subscribe per product so that makers can scale
+// horizontally and independently per coffee type.
+func OrderSubject(productID string) string {
+	return fmt.Sprintf("coffee.orders.%s", productID)
+}
+
+// OrderPublisher writes orders to a JetStream stream, one subject per
+// product ID.
+type OrderPublisher struct {
+	js         nats.JetStreamContext
+	streamName string
+}
+
+// NewOrderPublisher creates an OrderPublisher that publishes onto streamName
+// using js.
+func NewOrderPublisher(js nats.JetStreamContext, streamName string) *OrderPublisher {
+	return &OrderPublisher{js: js, streamName: streamName}
+}
+
+// Publish writes the order to its product subject and returns once
+// JetStream has acked the write.
+func (p *OrderPublisher) Publish(order Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	_, err = p.js.Publish(OrderSubject(order.ProductID), data)
+	return err
+}
+
+// setupJetStream creates the order stream and the orders-values KV
+// bucket if they don't already exist. Per-product durable consumers are
+// created lazily by maker.New, since only then is the product known.
+func setupJetStream(js nats.JetStreamContext, streamName string) (nats.KeyValue, error) {
+	if _, err := js.StreamInfo(streamName); err != nil {
+		if _, err := js.AddStream(&nats.StreamConfig{
+			Name:     streamName,
+			Subjects: []string{"coffee.orders.*"},
+		}); err != nil {
+			return nil, fmt.Errorf("add stream %s: %w", streamName, err)
+		}
+	}
+
+	kv, err := js.KeyValue(OrdersValuesBucket)
+	if err != nil {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: OrdersValuesBucket})
+		if err != nil {
+			return nil, fmt.Errorf("create kv bucket %s: %w", OrdersValuesBucket, err)
+		}
+	}
+
+	if _, err := kv.Get(OrdersPendingKey); err != nil {
+		if !errors.Is(err, nats.ErrKeyNotFound) {
+			return nil, fmt.Errorf("read %s: %w", OrdersPendingKey, err)
+		}
+		if _, err := kv.Put(OrdersPendingKey, []byte("0")); err != nil {
+			return nil, fmt.Errorf("init %s: %w", OrdersPendingKey, err)
+		}
+	}
+
+	return kv, nil
+}
+
+// WithJetStream connects to the NATS server at url and creates
+// streamName, plus the orders-values KV bucket, so the server can
+// accept orders and report pending counts. It creates no consumer and
+// does not use consumerName; CoffeeMaker workers create their
+// per-product durable consumers in maker.New from the consumerName
+// passed to it. It wires an OrderPublisher onto the returned Server and
+// drains the NATS connection on Shutdown.
+func WithJetStream(url, streamName, consumerName string) option {
+	return func(s *Server) error {
+		nc, err := nats.Connect(url)
+		if err != nil {
+			return fmt.Errorf("connect to nats: %w", err)
+		}
+
+		js, err := nc.JetStream()
+		if err != nil {
+			nc.Close()
+			return fmt.Errorf("jetstream context: %w", err)
+		}
+
+		kv, err := setupJetStream(js, streamName)
+		if err != nil {
+			nc.Close()
+			return err
+		}
+
+		s.natsConn = nc
+		s.JetStream = js
+		s.OrdersKV = kv
+		s.Orders = NewOrderPublisher(js, streamName)
+		return nil
+	}
+}
+
+// PostOrder accepts an order for a product and publishes it to JetStream
+// for a CoffeeMaker worker to pick up.
+func (cs *Server) PostOrder(w http.ResponseWriter, r *http.Request) {
+	if cs.Orders == nil {
+		http.Error(w, "ordering is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var order Order
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		http.Error(w, "invalid order", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cs.Store.GetProduct(r.Context(), order.ProductID); err != nil {
+		http.Error(w, "product not found", http.StatusNotFound)
+		return
+	}
+
+	if order.ID == "" {
+		order.ID = nats.NewInbox()
+	}
+	order.CreatedAt = time.Now()
+
+	// Increment before publishing: GET /orders/pending must never
+	// undercount an order that's actually in flight. If the publish
+	// below then fails, we haven't told the client it succeeded, so we
+	// compensate the count back down rather than leaving it stuck high.
+	if err := cs.incrementPending(1); err != nil {
+		http.Error(w, "could not update pending count", http.StatusInternalServerError)
+		return
+	}
+
+	if err := cs.Orders.Publish(order); err != nil {
+		if compErr := cs.incrementPending(-1); compErr != nil {
+			log.Printf("coffeeshop: compensating pending count after failed publish of order %s: %v", order.ID, compErr)
+		}
+		http.Error(w, "could not place order", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(order)
+}
+
+// GetPendingOrders returns the number of orders that have been placed
+// but not yet processed by a CoffeeMaker.
+func (cs *Server) GetPendingOrders(w http.ResponseWriter, r *http.Request) {
+	if cs.OrdersKV == nil {
+		http.Error(w, "ordering is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	entry, err := cs.OrdersKV.Get(OrdersPendingKey)
+	if err != nil {
+		http.Error(w, "could not read pending count", http.StatusInternalServerError)
+		return
+	}
+
+	pending, err := strconv.Atoi(string(entry.Value()))
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Pending int `json:"pending"`
+	}{Pending: pending})
+}
+
+func (cs *Server) incrementPending(delta int) error {
+	for {
+		entry, err := cs.OrdersKV.Get(OrdersPendingKey)
+		if err != nil {
+			return err
+		}
+		current, err := strconv.Atoi(string(entry.Value()))
+		if err != nil {
+			return err
+		}
+		next := current + delta
+		if next < 0 {
+			next = 0
+		}
+		_, err = cs.OrdersKV.Update(OrdersPendingKey, []byte(strconv.Itoa(next)), entry.Revision())
+		if err != nil && errors.Is(err, nats.ErrKeyExists) {
+			continue
+		}
+		return err
+	}
+}
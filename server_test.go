@@ -0,0 +1,98 @@
+package coffeeshop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDelayWaitsForLatency(t *testing.T) {
+	called := false
+	handler := Delay(func() time.Duration { return 20 * time.Millisecond })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+
+	start := time.Now()
+	handler.ServeHTTP(rec, req)
+	elapsed := time.Since(start)
+
+	if !called {
+		t.Fatal("next handler was not called")
+	}
+	if elapsed < 20*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least 20ms", elapsed)
+	}
+}
+
+func TestDelayAbortsOnContextCancellation(t *testing.T) {
+	called := false
+	handler := Delay(func() time.Duration { return time.Hour })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/products", nil).WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rec, req)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Delay did not return promptly after context cancellation")
+	}
+	if called {
+		t.Error("next handler was called despite context cancellation")
+	}
+}
+
+func TestServerSetLatency(t *testing.T) {
+	srv := New(":0", &MemoryStore{})
+
+	srv.SetLatency(50 * time.Millisecond)
+	if got := srv.Latency(); got != 50*time.Millisecond {
+		t.Errorf("Latency() = %v, want 50ms", got)
+	}
+
+	srv.SetLatency(0)
+	if got := srv.Latency(); got != 0 {
+		t.Errorf("Latency() = %v, want 0", got)
+	}
+}
+
+func TestMemoryStoreGetAllCanceledContext(t *testing.T) {
+	ms := &MemoryStore{Products: Products{"1": {ID: "1"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got := ms.GetAll(ctx); got != nil {
+		t.Errorf("GetAll() = %v, want nil for canceled context", got)
+	}
+}
+
+func TestMemoryStoreGetProductCanceledContext(t *testing.T) {
+	ms := &MemoryStore{Products: Products{"1": {ID: "1"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ms.GetProduct(ctx, "1"); err == nil {
+		t.Fatal("GetProduct: want error for canceled context")
+	}
+}
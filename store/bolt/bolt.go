@@ -0,0 +1,136 @@
+// Package bolt implements coffeeshop.Store on top of an embedded BoltDB
+// file, with each product stored as a JSON value keyed by its ID.
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/jjngx/coffeeshop"
+)
+
+// productsBucket is the single bucket products are stored in.
+var productsBucket = []byte("products")
+
+// Store is a coffeeshop.Store backed by an embedded BoltDB file.
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open opens (creating if necessary) the BoltDB file at path and
+// ensures the products bucket exists.
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(productsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create products bucket: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// GetAll returns every product in the bucket. It returns nil if ctx is
+// canceled before the read completes.
+func (s *Store) GetAll(ctx context.Context) []coffeeshop.Product {
+	if ctx.Err() != nil {
+		return nil
+	}
+	products, err := s.List(coffeeshop.Filter{})
+	if err != nil {
+		return nil
+	}
+	return products
+}
+
+// GetProduct returns the product with the given id.
+// It errors if the product with requested ID does not exist, or if ctx
+// is canceled before the read completes.
+func (s *Store) GetProduct(ctx context.Context, id string) (coffeeshop.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return coffeeshop.Product{}, err
+	}
+	var product coffeeshop.Product
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(productsBucket).Get([]byte(id))
+		if data == nil {
+			return errors.New("product not found")
+		}
+		return json.Unmarshal(data, &product)
+	})
+	if err != nil {
+		return coffeeshop.Product{}, err
+	}
+	return product, nil
+}
+
+// Put inserts p, or replaces the existing product with the same ID.
+func (s *Store) Put(p coffeeshop.Product) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(productsBucket).Put([]byte(p.ID), data)
+	})
+}
+
+// Delete removes the product with the given id.
+// It errors if the product with requested ID does not exist.
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(productsBucket)
+		if b.Get([]byte(id)) == nil {
+			return errors.New("product not found")
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// LabelNames returns the label names the store knows how to filter on.
+func (s *Store) LabelNames() []string {
+	return coffeeshop.LabelNames(s.GetAll(context.Background()))
+}
+
+// LabelValues returns the distinct values seen for label name.
+func (s *Store) LabelValues(name string) []string {
+	return coffeeshop.LabelValues(s.GetAll(context.Background()), name)
+}
+
+// List returns the products matching filter.
+func (s *Store) List(filter coffeeshop.Filter) ([]coffeeshop.Product, error) {
+	products := make([]coffeeshop.Product, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(productsBucket).ForEach(func(_, data []byte) error {
+			var p coffeeshop.Product
+			if err := json.Unmarshal(data, &p); err != nil {
+				return err
+			}
+			if filter.Matches(p) {
+				products = append(products, p)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
@@ -0,0 +1,170 @@
+package bolt
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jjngx/coffeeshop"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "coffeeshop.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStorePutGetProduct(t *testing.T) {
+	s := openTestStore(t)
+
+	p := coffeeshop.Product{ID: "1", Brand: "illy", Type: "Coffee", Price: "7.99"}
+	if err := s.Put(p); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, err := s.GetProduct(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("GetProduct() = %+v, want %+v", got, p)
+	}
+}
+
+func TestStoreGetProductNotFound(t *testing.T) {
+	s := openTestStore(t)
+
+	if _, err := s.GetProduct(context.Background(), "missing"); err == nil {
+		t.Fatal("GetProduct: want error for missing product")
+	}
+}
+
+func TestStoreGetProductCanceledContext(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.Put(coffeeshop.Product{ID: "1"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.GetProduct(ctx, "1"); err == nil {
+		t.Fatal("GetProduct: want error for canceled context")
+	}
+}
+
+func TestStoreGetAll(t *testing.T) {
+	s := openTestStore(t)
+	want := []coffeeshop.Product{
+		{ID: "1", Brand: "illy"},
+		{ID: "2", Brand: "Lavazza"},
+	}
+	for _, p := range want {
+		if err := s.Put(p); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	got := s.GetAll(context.Background())
+	if len(got) != len(want) {
+		t.Fatalf("GetAll() = %v, want %v", got, want)
+	}
+}
+
+func TestStoreGetAllCanceledContext(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.Put(coffeeshop.Product{ID: "1"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got := s.GetAll(ctx); got != nil {
+		t.Errorf("GetAll() = %v, want nil for canceled context", got)
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.Put(coffeeshop.Product{ID: "1"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if err := s.Delete("1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.GetProduct(context.Background(), "1"); err == nil {
+		t.Fatal("GetProduct: want error after Delete")
+	}
+}
+
+func TestStoreDeleteNotFound(t *testing.T) {
+	s := openTestStore(t)
+	if err := s.Delete("missing"); err == nil {
+		t.Fatal("Delete: want error for missing product")
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	s := openTestStore(t)
+	products := []coffeeshop.Product{
+		{ID: "1", Brand: "illy", Price: "7.99"},
+		{ID: "2", Brand: "Lavazza", Price: "12.99"},
+	}
+	for _, p := range products {
+		if err := s.Put(p); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	got, err := s.List(coffeeshop.Filter{Brand: "illy"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("List(Brand=illy) = %v, want just product 1", got)
+	}
+}
+
+func TestStoreListEmptyIsNotNil(t *testing.T) {
+	s := openTestStore(t)
+
+	got, err := s.List(coffeeshop.Filter{Brand: "does-not-exist"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if got == nil {
+		t.Error("List() = nil, want empty non-nil slice")
+	}
+}
+
+func TestStoreLabelNamesAndValues(t *testing.T) {
+	s := openTestStore(t)
+	products := []coffeeshop.Product{
+		{ID: "1", Brand: "illy", Type: "Coffee", Price: "7.99"},
+		{ID: "2", Brand: "Lavazza", Type: "Coffee", Price: "12.99"},
+	}
+	for _, p := range products {
+		if err := s.Put(p); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	names := s.LabelNames()
+	wantNames := []string{"brand", "type"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("LabelNames() = %v, want %v", names, wantNames)
+	}
+
+	values := s.LabelValues("brand")
+	wantValues := []string{"Lavazza", "illy"}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("LabelValues(brand) = %v, want %v", values, wantValues)
+	}
+}
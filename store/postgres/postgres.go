@@ -0,0 +1,209 @@
+// Package postgres implements coffeeshop.Store on top of a Postgres
+// products table, with product Properties stored as a JSONB column.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+
+	"github.com/jjngx/coffeeshop"
+)
+
+// schema creates the products table used by Store. It is safe to run
+// repeatedly.
+const schema = `
+CREATE TABLE IF NOT EXISTS products (
+	id         TEXT PRIMARY KEY,
+	type       TEXT NOT NULL,
+	brand      TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	unit       TEXT,
+	quantity   TEXT,
+	price      TEXT,
+	properties JSONB NOT NULL DEFAULT '[]'
+);
+`
+
+// Store is a coffeeshop.Store backed by Postgres.
+type Store struct {
+	db *sql.DB
+}
+
+// Open connects to the Postgres instance at dsn and migrates the
+// products table if it doesn't already exist.
+func Open(dsn string) (*Store, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("migrate products table: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database connection.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func scanProduct(row interface{ Scan(...any) error }) (coffeeshop.Product, error) {
+	var p coffeeshop.Product
+	var properties []byte
+	if err := row.Scan(&p.ID, &p.Type, &p.Brand, &p.Name, &p.Unit, &p.Quantity, &p.Price, &properties); err != nil {
+		return coffeeshop.Product{}, err
+	}
+	if err := json.Unmarshal(properties, &p.Properties); err != nil {
+		return coffeeshop.Product{}, fmt.Errorf("unmarshal properties: %w", err)
+	}
+	return p, nil
+}
+
+// GetAll returns every product in the table. It returns nil if ctx is
+// canceled before the query completes.
+func (s *Store) GetAll(ctx context.Context) []coffeeshop.Product {
+	products, err := s.list(ctx, coffeeshop.Filter{})
+	if err != nil {
+		return nil
+	}
+	return products
+}
+
+// GetProduct returns the product with the given id.
+// It errors if the product with requested ID does not exist, or if ctx
+// is canceled before the query completes.
+func (s *Store) GetProduct(ctx context.Context, id string) (coffeeshop.Product, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, type, brand, name, unit, quantity, price, properties FROM products WHERE id = $1`, id)
+	p, err := scanProduct(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return coffeeshop.Product{}, errors.New("product not found")
+	}
+	if err != nil {
+		return coffeeshop.Product{}, err
+	}
+	return p, nil
+}
+
+// Put inserts p, or replaces the existing product with the same ID.
+func (s *Store) Put(p coffeeshop.Product) error {
+	properties, err := json.Marshal(p.Properties)
+	if err != nil {
+		return fmt.Errorf("marshal properties: %w", err)
+	}
+
+	_, err = s.db.ExecContext(context.Background(), `
+		INSERT INTO products (id, type, brand, name, unit, quantity, price, properties)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET
+			type = EXCLUDED.type,
+			brand = EXCLUDED.brand,
+			name = EXCLUDED.name,
+			unit = EXCLUDED.unit,
+			quantity = EXCLUDED.quantity,
+			price = EXCLUDED.price,
+			properties = EXCLUDED.properties
+	`, p.ID, p.Type, p.Brand, p.Name, p.Unit, p.Quantity, p.Price, properties)
+	return err
+}
+
+// Delete removes the product with the given id.
+// It errors if the product with requested ID does not exist.
+func (s *Store) Delete(id string) error {
+	res, err := s.db.ExecContext(context.Background(), `DELETE FROM products WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("product not found")
+	}
+	return nil
+}
+
+// LabelNames returns the label names the store knows how to filter on.
+func (s *Store) LabelNames() []string {
+	return coffeeshop.LabelNames(s.GetAll(context.Background()))
+}
+
+// LabelValues returns the distinct values seen for label name.
+func (s *Store) LabelValues(name string) []string {
+	return coffeeshop.LabelValues(s.GetAll(context.Background()), name)
+}
+
+// List returns the products matching filter.
+func (s *Store) List(filter coffeeshop.Filter) ([]coffeeshop.Product, error) {
+	return s.list(context.Background(), filter)
+}
+
+func (s *Store) list(ctx context.Context, filter coffeeshop.Filter) ([]coffeeshop.Product, error) {
+	query := `SELECT id, type, brand, name, unit, quantity, price, properties FROM products`
+	var clauses []string
+	var args []any
+
+	if filter.Brand != "" {
+		args = append(args, filter.Brand)
+		clauses = append(clauses, fmt.Sprintf("brand = $%d", len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		clauses = append(clauses, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if filter.MinPrice != 0 {
+		args = append(args, filter.MinPrice)
+		clauses = append(clauses, fmt.Sprintf("price::numeric >= $%d", len(args)))
+	}
+	if filter.MaxPrice != 0 {
+		args = append(args, filter.MaxPrice)
+		clauses = append(clauses, fmt.Sprintf("price::numeric <= $%d", len(args)))
+	}
+	for name, value := range filter.Labels {
+		switch name {
+		case "brand":
+			args = append(args, value)
+			clauses = append(clauses, fmt.Sprintf("brand = $%d", len(args)))
+		case "type":
+			args = append(args, value)
+			clauses = append(clauses, fmt.Sprintf("type = $%d", len(args)))
+		default:
+			args = append(args, name)
+			nameArg := len(args)
+			args = append(args, value)
+			valueArg := len(args)
+			clauses = append(clauses, fmt.Sprintf(
+				"EXISTS (SELECT 1 FROM jsonb_array_elements(properties) p WHERE p->>'name' = $%d AND p->>'value' = $%d)",
+				nameArg, valueArg))
+		}
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]coffeeshop.Product, 0)
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}